@@ -0,0 +1,388 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// CBORBuilder assembles a canonical CBOR (RFC 8949 §4.2.1) encoding into
+// an internal buffer: integers use the shortest form that represents
+// them, and map entries must be written in the order produced by
+// SortMapEntries.
+type CBORBuilder struct {
+	buf bytes.Buffer
+}
+
+// NewCBORBuilder returns an empty CBORBuilder.
+func NewCBORBuilder() *CBORBuilder {
+	return &CBORBuilder{}
+}
+
+// Bytes returns the encoded CBOR data written so far.
+func (b *CBORBuilder) Bytes() []byte {
+	return b.buf.Bytes()
+}
+
+// WriteRaw appends already-encoded CBOR data verbatim.
+func (b *CBORBuilder) WriteRaw(p []byte) {
+	b.buf.Write(p)
+}
+
+// writeHead writes a major-type/length head using the shortest form that
+// represents n, as canonical CBOR requires.
+func (b *CBORBuilder) writeHead(major byte, n uint64) {
+	switch {
+	case n < 24:
+		b.buf.WriteByte(major<<5 | byte(n))
+	case n <= 0xff:
+		b.buf.WriteByte(major<<5 | 24)
+		b.buf.WriteByte(byte(n))
+	case n <= 0xffff:
+		b.buf.WriteByte(major<<5 | 25)
+		var tmp [2]byte
+		binary.BigEndian.PutUint16(tmp[:], uint16(n))
+		b.buf.Write(tmp[:])
+	case n <= 0xffffffff:
+		b.buf.WriteByte(major<<5 | 26)
+		var tmp [4]byte
+		binary.BigEndian.PutUint32(tmp[:], uint32(n))
+		b.buf.Write(tmp[:])
+	default:
+		b.buf.WriteByte(major<<5 | 27)
+		var tmp [8]byte
+		binary.BigEndian.PutUint64(tmp[:], n)
+		b.buf.Write(tmp[:])
+	}
+}
+
+// WriteInt writes v as a CBOR integer (major type 0 or 1).
+func (b *CBORBuilder) WriteInt(v int64) {
+	if v >= 0 {
+		b.writeHead(0, uint64(v))
+		return
+	}
+	b.writeHead(1, uint64(-(v + 1)))
+}
+
+// WriteFloat writes v as a CBOR double-precision float (major type 7).
+func (b *CBORBuilder) WriteFloat(v float64) {
+	b.buf.WriteByte(0xfb)
+	var tmp [8]byte
+	binary.BigEndian.PutUint64(tmp[:], math.Float64bits(v))
+	b.buf.Write(tmp[:])
+}
+
+// WriteBool writes v as a CBOR simple value (major type 7).
+func (b *CBORBuilder) WriteBool(v bool) {
+	if v {
+		b.buf.WriteByte(0xf5)
+	} else {
+		b.buf.WriteByte(0xf4)
+	}
+}
+
+// WriteNull writes the CBOR null simple value.
+func (b *CBORBuilder) WriteNull() {
+	b.buf.WriteByte(0xf6)
+}
+
+// WriteTextString writes s as a CBOR text string (major type 3).
+func (b *CBORBuilder) WriteTextString(s string) {
+	b.writeHead(3, uint64(len(s)))
+	b.buf.WriteString(s)
+}
+
+// WriteArrayHeader writes the head of a definite-length array of n items;
+// callers must follow with exactly n encoded items.
+func (b *CBORBuilder) WriteArrayHeader(n int) {
+	b.writeHead(4, uint64(n))
+}
+
+// WriteMapHeader writes the head of a definite-length map of n entries;
+// callers must follow with exactly n key/value pairs in canonical order,
+// see SortMapEntries.
+func (b *CBORBuilder) WriteMapHeader(n int) {
+	b.writeHead(5, uint64(n))
+}
+
+// EncodedMapEntry is a map entry whose key and value have already been
+// CBOR-encoded, for use with SortMapEntries.
+type EncodedMapEntry struct {
+	Key   []byte
+	Value []byte
+}
+
+// SortMapEntries orders entries by the lexicographic byte order of their
+// encoded keys, as RFC 8949 §4.2.1 requires for canonical maps.
+func SortMapEntries(entries []EncodedMapEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].Key, entries[j].Key) < 0
+	})
+}
+
+// DecodedField is a single decoded top-level field, in the order it was
+// read off the wire. See DecodeCBORMapFields.
+type DecodedField struct {
+	Name  string
+	Value interface{}
+}
+
+// DecodeCBORMapFields decodes data as a CBOR map and returns its entries
+// in encounter order, i.e. the canonical encoded-key-byte order written
+// by CBORBuilder.WriteMapHeader/SortMapEntries. Unlike decoding through
+// DecodeCBORValue into a map[string]interface{}, this preserves that
+// order instead of discarding it to Go map iteration order, so callers
+// that care about a stable, canonical field order (such as
+// value.FieldListFromCBOR) don't need to re-sort by a different key
+// (e.g. lexicographic name) afterwards, which would disagree with the
+// canonical order for keys of differing lengths.
+func DecodeCBORMapFields(data []byte) ([]DecodedField, []byte, error) {
+	if len(data) == 0 {
+		return nil, nil, fmt.Errorf("unexpected end of CBOR input")
+	}
+	major := data[0] >> 5
+	if major != 5 {
+		return nil, nil, fmt.Errorf("expected a CBOR map, got major type %d", major)
+	}
+
+	n, rest, err := decodeMapHeaderLen(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	seen := make(map[string]bool, n)
+	fields := make([]DecodedField, 0, n)
+	for i := uint64(0); i < n; i++ {
+		var kv interface{}
+		kv, rest, err = DecodeCBORValue(rest)
+		if err != nil {
+			return nil, nil, err
+		}
+		k, ok := kv.(string)
+		if !ok {
+			return nil, nil, fmt.Errorf("CBOR map keys must be text strings")
+		}
+		if seen[k] {
+			return nil, nil, fmt.Errorf("duplicate CBOR map key %q", k)
+		}
+		seen[k] = true
+
+		var val interface{}
+		val, rest, err = DecodeCBORValue(rest)
+		if err != nil {
+			return nil, nil, err
+		}
+		fields = append(fields, DecodedField{Name: k, Value: val})
+	}
+
+	return fields, rest, nil
+}
+
+// decodeMapHeaderLen reads a CBOR map head (major type 5) from the front
+// of data and returns the number of entries it declares along with the
+// remaining bytes after the head.
+func decodeMapHeaderLen(data []byte) (uint64, []byte, error) {
+	info := data[0] & 0x1f
+	data = data[1:]
+	switch {
+	case info < 24:
+		return uint64(info), data, nil
+	case info == 24:
+		if len(data) < 1 {
+			return 0, nil, fmt.Errorf("truncated CBOR length")
+		}
+		return uint64(data[0]), data[1:], nil
+	case info == 25:
+		if len(data) < 2 {
+			return 0, nil, fmt.Errorf("truncated CBOR length")
+		}
+		return uint64(binary.BigEndian.Uint16(data[:2])), data[2:], nil
+	case info == 26:
+		if len(data) < 4 {
+			return 0, nil, fmt.Errorf("truncated CBOR length")
+		}
+		return uint64(binary.BigEndian.Uint32(data[:4])), data[4:], nil
+	case info == 27:
+		if len(data) < 8 {
+			return 0, nil, fmt.Errorf("truncated CBOR length")
+		}
+		return binary.BigEndian.Uint64(data[:8]), data[8:], nil
+	default:
+		return 0, nil, fmt.Errorf("indefinite-length CBOR items are not supported")
+	}
+}
+
+// DecodeCBORValue decodes a single CBOR data item from the front of data,
+// returning the decoded value and the remaining unconsumed bytes.
+// Integers decode to int64, floats to float64, byte/text strings to
+// []byte/string, arrays to []interface{} and maps to map[string]interface{}.
+// Indefinite-length items and maps with duplicate keys are rejected, as
+// required for canonical CBOR.
+func DecodeCBORValue(data []byte) (interface{}, []byte, error) {
+	if len(data) == 0 {
+		return nil, nil, fmt.Errorf("unexpected end of CBOR input")
+	}
+	head := data[0]
+	major := head >> 5
+	info := head & 0x1f
+	data = data[1:]
+
+	readLen := func() (uint64, error) {
+		switch {
+		case info < 24:
+			return uint64(info), nil
+		case info == 24:
+			if len(data) < 1 {
+				return 0, fmt.Errorf("truncated CBOR length")
+			}
+			n := uint64(data[0])
+			data = data[1:]
+			return n, nil
+		case info == 25:
+			if len(data) < 2 {
+				return 0, fmt.Errorf("truncated CBOR length")
+			}
+			n := uint64(binary.BigEndian.Uint16(data[:2]))
+			data = data[2:]
+			return n, nil
+		case info == 26:
+			if len(data) < 4 {
+				return 0, fmt.Errorf("truncated CBOR length")
+			}
+			n := uint64(binary.BigEndian.Uint32(data[:4]))
+			data = data[4:]
+			return n, nil
+		case info == 27:
+			if len(data) < 8 {
+				return 0, fmt.Errorf("truncated CBOR length")
+			}
+			n := binary.BigEndian.Uint64(data[:8])
+			data = data[8:]
+			return n, nil
+		default:
+			return 0, fmt.Errorf("indefinite-length CBOR items are not supported")
+		}
+	}
+
+	switch major {
+	case 0:
+		n, err := readLen()
+		if err != nil {
+			return nil, nil, err
+		}
+		return int64(n), data, nil
+	case 1:
+		n, err := readLen()
+		if err != nil {
+			return nil, nil, err
+		}
+		return -1 - int64(n), data, nil
+	case 2:
+		n, err := readLen()
+		if err != nil {
+			return nil, nil, err
+		}
+		if uint64(len(data)) < n {
+			return nil, nil, fmt.Errorf("truncated CBOR byte string")
+		}
+		b := append([]byte(nil), data[:n]...)
+		return b, data[n:], nil
+	case 3:
+		n, err := readLen()
+		if err != nil {
+			return nil, nil, err
+		}
+		if uint64(len(data)) < n {
+			return nil, nil, fmt.Errorf("truncated CBOR text string")
+		}
+		s := string(data[:n])
+		return s, data[n:], nil
+	case 4:
+		n, err := readLen()
+		if err != nil {
+			return nil, nil, err
+		}
+		arr := make([]interface{}, 0, n)
+		for i := uint64(0); i < n; i++ {
+			var v interface{}
+			var err error
+			v, data, err = DecodeCBORValue(data)
+			if err != nil {
+				return nil, nil, err
+			}
+			arr = append(arr, v)
+		}
+		return arr, data, nil
+	case 5:
+		n, err := readLen()
+		if err != nil {
+			return nil, nil, err
+		}
+		m := make(map[string]interface{}, n)
+		for i := uint64(0); i < n; i++ {
+			var kv interface{}
+			var err error
+			kv, data, err = DecodeCBORValue(data)
+			if err != nil {
+				return nil, nil, err
+			}
+			k, ok := kv.(string)
+			if !ok {
+				return nil, nil, fmt.Errorf("CBOR map keys must be text strings")
+			}
+			if _, dup := m[k]; dup {
+				return nil, nil, fmt.Errorf("duplicate CBOR map key %q", k)
+			}
+			var v interface{}
+			v, data, err = DecodeCBORValue(data)
+			if err != nil {
+				return nil, nil, err
+			}
+			m[k] = v
+		}
+		return m, data, nil
+	case 6:
+		// Tags are skipped; only the tagged value is kept.
+		if _, err := readLen(); err != nil {
+			return nil, nil, err
+		}
+		return DecodeCBORValue(data)
+	case 7:
+		switch info {
+		case 20:
+			return false, data, nil
+		case 21:
+			return true, data, nil
+		case 22:
+			return nil, data, nil
+		case 27:
+			if len(data) < 8 {
+				return nil, nil, fmt.Errorf("truncated CBOR float")
+			}
+			return math.Float64frombits(binary.BigEndian.Uint64(data[:8])), data[8:], nil
+		default:
+			return nil, nil, fmt.Errorf("unsupported CBOR simple value %d", info)
+		}
+	default:
+		return nil, nil, fmt.Errorf("unsupported CBOR major type %d", major)
+	}
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCBORScalarRoundTrip(t *testing.T) {
+	cases := []interface{}{
+		int64(0), int64(23), int64(24), int64(1000), int64(-1), int64(-1000),
+		"", "hello", 1.5, true, false, nil,
+	}
+	for _, c := range cases {
+		b := NewCBORBuilder()
+		switch v := c.(type) {
+		case int64:
+			b.WriteInt(v)
+		case string:
+			b.WriteTextString(v)
+		case float64:
+			b.WriteFloat(v)
+		case bool:
+			b.WriteBool(v)
+		case nil:
+			b.WriteNull()
+		}
+
+		got, rest, err := DecodeCBORValue(b.Bytes())
+		if err != nil {
+			t.Fatalf("decoding %v: %v", c, err)
+		}
+		if len(rest) != 0 {
+			t.Fatalf("decoding %v: unexpected trailing bytes %v", c, rest)
+		}
+		if !reflect.DeepEqual(got, c) {
+			t.Fatalf("round trip %v: got %#v, want %#v", c, got, c)
+		}
+	}
+}
+
+func TestCBORArrayRoundTrip(t *testing.T) {
+	b := NewCBORBuilder()
+	b.WriteArrayHeader(3)
+	b.WriteInt(1)
+	b.WriteTextString("two")
+	b.WriteBool(true)
+
+	got, rest, err := DecodeCBORValue(b.Bytes())
+	if err != nil {
+		t.Fatalf("decoding: %v", err)
+	}
+	if len(rest) != 0 {
+		t.Fatalf("unexpected trailing bytes: %v", rest)
+	}
+	want := []interface{}{int64(1), "two", true}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v, want %#v", got, want)
+	}
+}
+
+// TestCBORMapCanonicalKeyOrder checks that SortMapEntries orders entries
+// by encoded-key bytes, not by the keys' own lexicographic order, and
+// that DecodeCBORMapFields preserves exactly that order.
+func TestCBORMapCanonicalKeyOrder(t *testing.T) {
+	// "b" encodes shorter (and so sorts first as raw bytes) than "aa",
+	// even though "aa" < "b" lexicographically.
+	entries := []EncodedMapEntry{
+		mapEntry(t, "aa", 1),
+		mapEntry(t, "b", 2),
+	}
+	SortMapEntries(entries)
+
+	w := NewCBORBuilder()
+	w.WriteMapHeader(len(entries))
+	for _, e := range entries {
+		w.WriteRaw(e.Key)
+		w.WriteRaw(e.Value)
+	}
+
+	fields, rest, err := DecodeCBORMapFields(w.Bytes())
+	if err != nil {
+		t.Fatalf("decoding: %v", err)
+	}
+	if len(rest) != 0 {
+		t.Fatalf("unexpected trailing bytes: %v", rest)
+	}
+	if len(fields) != 2 || fields[0].Name != "b" || fields[1].Name != "aa" {
+		t.Fatalf("got fields %#v, want [b aa] (canonical byte order, not lexicographic)", fields)
+	}
+}
+
+func mapEntry(t *testing.T, key string, val int64) EncodedMapEntry {
+	t.Helper()
+	kb := NewCBORBuilder()
+	kb.WriteTextString(key)
+	vb := NewCBORBuilder()
+	vb.WriteInt(val)
+	return EncodedMapEntry{Key: kb.Bytes(), Value: vb.Bytes()}
+}
+
+func TestCBORDuplicateMapKeyRejected(t *testing.T) {
+	entries := []EncodedMapEntry{mapEntry(t, "a", 1), mapEntry(t, "a", 2)}
+
+	w := NewCBORBuilder()
+	w.WriteMapHeader(len(entries))
+	for _, e := range entries {
+		w.WriteRaw(e.Key)
+		w.WriteRaw(e.Value)
+	}
+
+	if _, _, err := DecodeCBORValue(w.Bytes()); err == nil {
+		t.Fatal("expected an error decoding a map with a duplicate key")
+	}
+	if _, _, err := DecodeCBORMapFields(w.Bytes()); err == nil {
+		t.Fatal("expected an error decoding fields from a map with a duplicate key")
+	}
+}
+
+func TestCBORIndefiniteLengthRejected(t *testing.T) {
+	cases := map[string][]byte{
+		"array": {0x9f, 0x01, 0xff},      // indefinite-length array
+		"map":   {0xbf, 0x61, 'a', 0x01}, // indefinite-length map
+		"text":  {0x7f, 0x61, 'a', 0xff}, // indefinite-length text string
+	}
+	for name, data := range cases {
+		if _, _, err := DecodeCBORValue(data); err == nil {
+			t.Errorf("%s: expected indefinite-length CBOR to be rejected", name)
+		}
+	}
+}
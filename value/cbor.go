@@ -0,0 +1,135 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package value
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/structured-merge-diff/v4/internal/builder"
+)
+
+// FieldListFromCBOR is the CBOR equivalent of FieldListFromJSON: it reads
+// a canonically-encoded CBOR map as a FieldList. Fields come back in the
+// canonical encoded-key-byte order they were written in (see
+// builder.SortMapEntries), not lexicographic name order, since those two
+// orders disagree whenever keys differ in length.
+func FieldListFromCBOR(input []byte) (FieldList, error) {
+	decoded, rest, err := builder.DecodeCBORMapFields(input)
+	if err != nil {
+		return nil, fmt.Errorf("parsing CBOR: %v", err)
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("unexpected trailing bytes after CBOR document")
+	}
+
+	fields := make(FieldList, 0, len(decoded))
+	for _, f := range decoded {
+		fields = append(fields, Field{Name: f.Name, Value: NewValueInterface(f.Value)})
+	}
+	return fields, nil
+}
+
+// FieldListToCBOR is the CBOR equivalent of FieldListToJSON: it writes v
+// as a canonically-encoded CBOR map, with entries ordered by the
+// lexicographic byte order of their encoded keys as RFC 8949 §4.2.1
+// requires.
+func FieldListToCBOR(v FieldList, w *builder.CBORBuilder) error {
+	entries := make([]builder.EncodedMapEntry, 0, len(v))
+	for _, f := range v {
+		kb := builder.NewCBORBuilder()
+		kb.WriteTextString(f.Name)
+
+		vb := builder.NewCBORBuilder()
+		if err := encodeCBORInterface(f.Value.Unstructured(), vb); err != nil {
+			return fmt.Errorf("encoding field %q: %v", f.Name, err)
+		}
+
+		entries = append(entries, builder.EncodedMapEntry{Key: kb.Bytes(), Value: vb.Bytes()})
+	}
+	builder.SortMapEntries(entries)
+
+	w.WriteMapHeader(len(entries))
+	for _, e := range entries {
+		w.WriteRaw(e.Key)
+		w.WriteRaw(e.Value)
+	}
+	return nil
+}
+
+// ValueFromCBOR decodes a single canonically-encoded CBOR value.
+func ValueFromCBOR(data []byte) (Value, error) {
+	v, rest, err := builder.DecodeCBORValue(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing CBOR: %v", err)
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("unexpected trailing bytes after CBOR value")
+	}
+	return NewValueInterface(v), nil
+}
+
+// ValueToCBOR writes v as canonically-encoded CBOR.
+func ValueToCBOR(v Value, w *builder.CBORBuilder) error {
+	return encodeCBORInterface(v.Unstructured(), w)
+}
+
+// encodeCBORInterface encodes the unstructured form of a Value, sorting
+// map entries into canonical order as it goes.
+func encodeCBORInterface(v interface{}, w *builder.CBORBuilder) error {
+	switch t := v.(type) {
+	case nil:
+		w.WriteNull()
+	case bool:
+		w.WriteBool(t)
+	case int64:
+		w.WriteInt(t)
+	case float64:
+		w.WriteFloat(t)
+	case string:
+		w.WriteTextString(t)
+	case []interface{}:
+		w.WriteArrayHeader(len(t))
+		for _, elem := range t {
+			if err := encodeCBORInterface(elem, w); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		entries := make([]builder.EncodedMapEntry, 0, len(t))
+		for k, val := range t {
+			kb := builder.NewCBORBuilder()
+			kb.WriteTextString(k)
+
+			vb := builder.NewCBORBuilder()
+			if err := encodeCBORInterface(val, vb); err != nil {
+				return fmt.Errorf("encoding key %q: %v", k, err)
+			}
+
+			entries = append(entries, builder.EncodedMapEntry{Key: kb.Bytes(), Value: vb.Bytes()})
+		}
+		builder.SortMapEntries(entries)
+
+		w.WriteMapHeader(len(entries))
+		for _, e := range entries {
+			w.WriteRaw(e.Key)
+			w.WriteRaw(e.Value)
+		}
+	default:
+		return fmt.Errorf("cannot encode %T as CBOR", v)
+	}
+	return nil
+}
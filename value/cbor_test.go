@@ -0,0 +1,104 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package value
+
+import (
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/internal/builder"
+)
+
+func TestFieldListCBORRoundTrip(t *testing.T) {
+	in := FieldList{
+		{Name: "replicas", Value: NewValueInterface(int64(3))},
+		{Name: "name", Value: NewValueInterface("nginx")},
+		{Name: "paused", Value: NewValueInterface(true)},
+	}
+
+	w := builder.NewCBORBuilder()
+	if err := FieldListToCBOR(in, w); err != nil {
+		t.Fatalf("encoding: %v", err)
+	}
+
+	out, err := FieldListFromCBOR(w.Bytes())
+	if err != nil {
+		t.Fatalf("decoding: %v", err)
+	}
+
+	if !in.sortedCopy().Equals(out.sortedCopy()) {
+		t.Fatalf("round trip mismatch: got %#v, want %#v", out, in)
+	}
+}
+
+// TestFieldListCBORCanonicalOrder checks that FieldListFromCBOR hands
+// back fields in the same canonical, encoded-key-byte order that
+// FieldListToCBOR wrote them in, rather than re-sorting them by name (the
+// two orders disagree whenever field names differ in length).
+func TestFieldListCBORCanonicalOrder(t *testing.T) {
+	in := FieldList{
+		{Name: "aa", Value: NewValueInterface(int64(1))},
+		{Name: "b", Value: NewValueInterface(int64(2))},
+	}
+
+	w := builder.NewCBORBuilder()
+	if err := FieldListToCBOR(in, w); err != nil {
+		t.Fatalf("encoding: %v", err)
+	}
+
+	out, err := FieldListFromCBOR(w.Bytes())
+	if err != nil {
+		t.Fatalf("decoding: %v", err)
+	}
+
+	if len(out) != 2 || out[0].Name != "b" || out[1].Name != "aa" {
+		t.Fatalf("got field order %v, want [b aa]", fieldNames(out))
+	}
+}
+
+func fieldNames(f FieldList) []string {
+	names := make([]string, len(f))
+	for i, field := range f {
+		names[i] = field.Name
+	}
+	return names
+}
+
+func TestFieldListFromCBORRejectsDuplicateKeys(t *testing.T) {
+	entries := []builder.EncodedMapEntry{
+		cborField(t, "a", 1),
+		cborField(t, "a", 2),
+	}
+	w := builder.NewCBORBuilder()
+	w.WriteMapHeader(len(entries))
+	for _, e := range entries {
+		w.WriteRaw(e.Key)
+		w.WriteRaw(e.Value)
+	}
+
+	if _, err := FieldListFromCBOR(w.Bytes()); err == nil {
+		t.Fatal("expected an error decoding a CBOR object with a duplicate key")
+	}
+}
+
+func cborField(t *testing.T, name string, val int64) builder.EncodedMapEntry {
+	t.Helper()
+	kb := builder.NewCBORBuilder()
+	kb.WriteTextString(name)
+	vb := builder.NewCBORBuilder()
+	vb.WriteInt(val)
+	return builder.EncodedMapEntry{Key: kb.Bytes(), Value: vb.Bytes()}
+}
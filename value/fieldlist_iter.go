@@ -0,0 +1,200 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package value
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"sigs.k8s.io/structured-merge-diff/v4/internal/builder"
+)
+
+// FieldIter lazily yields the top-level fields of a JSON object without
+// eagerly unmarshaling their values. This lets callers that only need a
+// handful of fields from a large object (e.g. a ConfigMap or CRD) avoid
+// paying to unmarshal the rest; the input itself is still held in memory
+// as a single []byte, it is only the per-field unmarshal that is
+// deferred.
+type FieldIter interface {
+	// Next returns the name and raw JSON of the next field. It returns
+	// io.EOF once the object has been fully consumed.
+	Next() (name string, raw json.RawMessage, err error)
+}
+
+// FieldListIterFromBytes returns a FieldIter over the JSON object in
+// input. Field values are handed back as raw JSON; use LazyValue (or
+// builder.UnmarshalInterface directly) to unmarshal only the ones a
+// caller actually needs. input is not copied and must not be modified
+// while the returned FieldIter is in use.
+func FieldListIterFromBytes(input []byte) (FieldIter, error) {
+	parser := builder.NewFastObjParser(input)
+
+	return &fieldIter{next: func() (string, json.RawMessage, error) {
+		rawKey, err := parser.Parse()
+		if err == io.EOF {
+			return "", nil, io.EOF
+		} else if err != nil {
+			return "", nil, fmt.Errorf("parsing JSON: %v", err)
+		}
+
+		rawValue, err := parser.Parse()
+		if err == io.EOF {
+			return "", nil, fmt.Errorf("unexpected EOF")
+		} else if err != nil {
+			return "", nil, fmt.Errorf("parsing JSON: %v", err)
+		}
+
+		name, err := builder.UnmarshalString(rawKey)
+		if err != nil {
+			return "", nil, fmt.Errorf("parsing JSON: %v", err)
+		}
+
+		return name, json.RawMessage(rawValue), nil
+	}}, nil
+}
+
+// FieldListIterFromReader reads r fully into memory and returns a
+// FieldIter over it, as FieldListIterFromBytes would. It does not stream
+// from r: the underlying parser works over a []byte, so the whole input
+// is buffered upfront regardless of how it was read. Prefer
+// FieldListIterFromBytes directly when the input is already a []byte, to
+// avoid the extra copy io.ReadAll makes.
+func FieldListIterFromReader(r io.Reader) (FieldIter, error) {
+	input, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading input: %v", err)
+	}
+	return FieldListIterFromBytes(input)
+}
+
+type fieldIter struct {
+	next func() (string, json.RawMessage, error)
+}
+
+func (it *fieldIter) Next() (string, json.RawMessage, error) {
+	return it.next()
+}
+
+// LazyValue wraps a field's raw JSON and only unmarshals it into a Value
+// the first time one of its accessors is called. Once resolved, the
+// result is cached.
+type LazyValue struct {
+	raw  json.RawMessage
+	once sync.Once
+	val  Value
+	err  error
+}
+
+// NewLazyValue wraps raw as a LazyValue. raw is not copied.
+func NewLazyValue(raw json.RawMessage) *LazyValue {
+	return &LazyValue{raw: raw}
+}
+
+func (l *LazyValue) resolve() (Value, error) {
+	l.once.Do(func() {
+		v, err := builder.UnmarshalInterface(l.raw)
+		if err != nil {
+			l.err = fmt.Errorf("parsing JSON: %v", err)
+			return
+		}
+		l.val = NewValueInterface(v)
+	})
+	return l.val, l.err
+}
+
+// AsValue returns the fully unmarshaled Value, parsing it on first use.
+func (l *LazyValue) AsValue() (Value, error) {
+	return l.resolve()
+}
+
+// AsMap returns the value as a Map, or false if it isn't one.
+func (l *LazyValue) AsMap() (Map, bool) {
+	v, err := l.resolve()
+	if err != nil || !v.IsMap() {
+		return nil, false
+	}
+	return v.AsMap(), true
+}
+
+// AsList returns the value as a List, or false if it isn't one.
+func (l *LazyValue) AsList() (List, bool) {
+	v, err := l.resolve()
+	if err != nil || !v.IsList() {
+		return nil, false
+	}
+	return v.AsList(), true
+}
+
+// AsString returns the value as a string, or false if it isn't one.
+func (l *LazyValue) AsString() (string, bool) {
+	v, err := l.resolve()
+	if err != nil || !v.IsString() {
+		return "", false
+	}
+	return v.AsString(), true
+}
+
+// FieldListFromJSONKeys parses the top-level fields of a JSON object,
+// but only unmarshals the values of fields whose name is in keys; all
+// other values are skipped over unparsed. This avoids the allocation
+// cost of FieldListFromJSON on cold paths that only need a few fields
+// out of a large object.
+func FieldListFromJSONKeys(input []byte, keys []string) (FieldList, error) {
+	wanted := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		wanted[k] = struct{}{}
+	}
+
+	parser := builder.NewFastObjParser(input)
+
+	var fields FieldList
+	for {
+		rawKey, err := parser.Parse()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("parsing JSON: %v", err)
+		}
+
+		rawValue, err := parser.Parse()
+		if err == io.EOF {
+			return nil, fmt.Errorf("unexpected EOF")
+		} else if err != nil {
+			return nil, fmt.Errorf("parsing JSON: %v", err)
+		}
+
+		name, err := builder.UnmarshalString(rawKey)
+		if err != nil {
+			return nil, fmt.Errorf("parsing JSON: %v", err)
+		}
+
+		if _, ok := wanted[name]; !ok {
+			continue
+		}
+
+		v, err := builder.UnmarshalInterface(rawValue)
+		if err != nil {
+			return nil, fmt.Errorf("parsing JSON: %v", err)
+		}
+
+		fields = append(fields, Field{Name: name, Value: NewValueInterface(v)})
+	}
+
+	return fields, nil
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package value
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func drainFieldIter(t *testing.T, it FieldIter) []string {
+	t.Helper()
+	var names []string
+	for {
+		name, _, err := it.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next(): %v", err)
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestFieldListIterFromBytes(t *testing.T) {
+	it, err := FieldListIterFromBytes([]byte(`{"a":1,"b":"two"}`))
+	if err != nil {
+		t.Fatalf("FieldListIterFromBytes: %v", err)
+	}
+
+	want := []string{"a", "b"}
+	got := drainFieldIter(t, it)
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestFieldListIterFromReaderMatchesFromBytes(t *testing.T) {
+	input := []byte(`{"a":1,"b":"two"}`)
+
+	it, err := FieldListIterFromReader(bytes.NewReader(input))
+	if err != nil {
+		t.Fatalf("FieldListIterFromReader: %v", err)
+	}
+
+	want := drainFieldIter(t, mustFieldIter(t, input))
+	got := drainFieldIter(t, it)
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func mustFieldIter(t *testing.T, input []byte) FieldIter {
+	t.Helper()
+	it, err := FieldListIterFromBytes(input)
+	if err != nil {
+		t.Fatalf("FieldListIterFromBytes: %v", err)
+	}
+	return it
+}
+
+func TestLazyValueResolvesOnFirstUse(t *testing.T) {
+	l := NewLazyValue([]byte(`"hello"`))
+
+	s, ok := l.AsString()
+	if !ok || s != "hello" {
+		t.Fatalf("AsString() = %q, %v; want %q, true", s, ok, "hello")
+	}
+
+	if _, ok := l.AsMap(); ok {
+		t.Fatal("AsMap() on a string LazyValue returned ok")
+	}
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package value
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/structured-merge-diff/v4/internal/builder"
+)
+
+// FieldMap is a field collection with the same contents as a FieldList,
+// but backed by an insertion-ordered slice plus a name index, giving
+// O(1) Get and Set instead of the O(n) scan (or sort-then-search)
+// FieldList requires. Delete is O(n): it shifts the later fields down
+// and reindexes them. Prefer FieldMap over FieldList for large objects
+// that are looked up by name repeatedly, such as during a merge.
+//
+// FieldMapFromList, FieldMapFromJSON and FieldMapFromUnstructured are the
+// entry points for building a FieldMap from the representations this
+// package already has callers for. FieldMap does not implement this
+// package's Map interface, and no Map implementation returns a FieldMap
+// view of its fields.
+type FieldMap struct {
+	fields []Field
+	index  map[string]int
+}
+
+// NewFieldMap returns an empty FieldMap.
+func NewFieldMap() *FieldMap {
+	return &FieldMap{index: map[string]int{}}
+}
+
+// FieldMapFromList builds a FieldMap from a FieldList, preserving order.
+// If f contains duplicate names, the last value for each name wins.
+func FieldMapFromList(f FieldList) *FieldMap {
+	m := &FieldMap{
+		fields: make([]Field, 0, len(f)),
+		index:  make(map[string]int, len(f)),
+	}
+	for _, field := range f {
+		m.Set(field.Name, field.Value)
+	}
+	return m
+}
+
+// ToList returns the fields as a FieldList, in insertion order.
+func (m *FieldMap) ToList() FieldList {
+	out := make(FieldList, len(m.fields))
+	copy(out, m.fields)
+	return out
+}
+
+// Len returns the number of fields.
+func (m *FieldMap) Len() int {
+	return len(m.fields)
+}
+
+// Get returns the value for name, or false if it isn't present.
+func (m *FieldMap) Get(name string) (Value, bool) {
+	i, ok := m.index[name]
+	if !ok {
+		return nil, false
+	}
+	return m.fields[i].Value, true
+}
+
+// Set sets the value for name, appending it if it isn't already present.
+func (m *FieldMap) Set(name string, v Value) {
+	if i, ok := m.index[name]; ok {
+		m.fields[i].Value = v
+		return
+	}
+	m.index[name] = len(m.fields)
+	m.fields = append(m.fields, Field{Name: name, Value: v})
+}
+
+// Delete removes name, if present.
+func (m *FieldMap) Delete(name string) {
+	i, ok := m.index[name]
+	if !ok {
+		return
+	}
+	m.fields = append(m.fields[:i], m.fields[i+1:]...)
+	delete(m.index, name)
+	for j := i; j < len(m.fields); j++ {
+		m.index[m.fields[j].Name] = j
+	}
+}
+
+// Range calls fn for each field in insertion order, stopping early if fn
+// returns false.
+func (m *FieldMap) Range(fn func(name string, v Value) bool) {
+	for _, f := range m.fields {
+		if !fn(f.Name, f.Value) {
+			return
+		}
+	}
+}
+
+// sortedCopy returns a sorted copy of f, for lexical comparisons that
+// don't depend on insertion order.
+func (f FieldList) sortedCopy() FieldList {
+	out := make(FieldList, len(f))
+	copy(out, f)
+	out.Sort()
+	return out
+}
+
+// Compare compares two field maps lexically by name, with the same
+// semantics as FieldList.Compare. Unlike Get/Set/Delete this is not O(1):
+// a lexical ordering comparison inherently needs both sides in name
+// order, so it costs one sort per side.
+func (m *FieldMap) Compare(rhs *FieldMap) int {
+	a := m.ToList()
+	a.Sort()
+	b := rhs.ToList()
+	b.Sort()
+	return a.Compare(b)
+}
+
+// Equals returns true if m and rhs have the same fields, regardless of
+// insertion order. This uses the index for O(1) lookups per field,
+// rather than sorting both sides first.
+func (m *FieldMap) Equals(rhs *FieldMap) bool {
+	if m.Len() != rhs.Len() {
+		return false
+	}
+	for _, f := range m.fields {
+		rv, ok := rhs.Get(f.Name)
+		if !ok || !Equals(f.Value, rv) {
+			return false
+		}
+	}
+	return true
+}
+
+// FieldMapFromJSON reads a JSON document directly into a FieldMap.
+func FieldMapFromJSON(input []byte) (*FieldMap, error) {
+	fields, err := FieldListFromJSON(input)
+	if err != nil {
+		return nil, err
+	}
+	return FieldMapFromList(fields), nil
+}
+
+// FieldMapFromUnstructured builds a FieldMap from a map[string]interface{}
+// such as the one produced by an unstructured object's Unstructured()
+// method or by builder.UnmarshalInterface. Field order is not defined by
+// m, so the resulting FieldMap's insertion order is arbitrary; callers
+// that need a stable order should sort the result with ToList().Sort().
+func FieldMapFromUnstructured(m map[string]interface{}) *FieldMap {
+	out := &FieldMap{
+		fields: make([]Field, 0, len(m)),
+		index:  make(map[string]int, len(m)),
+	}
+	for k, v := range m {
+		out.Set(k, NewValueInterface(v))
+	}
+	return out
+}
+
+// FieldMapToJSON writes m as a JSON document.
+func FieldMapToJSON(m *FieldMap, w *builder.JSONBuilder) error {
+	if err := FieldListToJSON(m.ToList(), w); err != nil {
+		return fmt.Errorf("writing JSON: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package value
+
+import (
+	"fmt"
+	"testing"
+)
+
+// benchFieldCount mirrors the >100 field objects (large CRDs, merged
+// status objects) that motivate FieldMap.
+const benchFieldCount = 256
+
+func benchFieldList(n int) FieldList {
+	out := make(FieldList, n)
+	for i := 0; i < n; i++ {
+		out[i] = Field{Name: fmt.Sprintf("field%04d", i), Value: NewValueInterface(int64(i))}
+	}
+	return out
+}
+
+func BenchmarkFieldListGet(b *testing.B) {
+	list := benchFieldList(benchFieldCount)
+	target := list[benchFieldCount-1].Name
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, f := range list {
+			if f.Name == target {
+				break
+			}
+		}
+	}
+}
+
+func BenchmarkFieldMapGet(b *testing.B) {
+	m := FieldMapFromList(benchFieldList(benchFieldCount))
+	target := fmt.Sprintf("field%04d", benchFieldCount-1)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Get(target)
+	}
+}
+
+func BenchmarkFieldListEquals(b *testing.B) {
+	a := benchFieldList(benchFieldCount)
+	c := benchFieldList(benchFieldCount)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		a.Equals(c)
+	}
+}
+
+func BenchmarkFieldMapEquals(b *testing.B) {
+	a := FieldMapFromList(benchFieldList(benchFieldCount))
+	c := FieldMapFromList(benchFieldList(benchFieldCount))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		a.Equals(c)
+	}
+}
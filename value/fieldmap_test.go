@@ -0,0 +1,142 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package value
+
+import "testing"
+
+func TestFieldMapGetSetDelete(t *testing.T) {
+	m := NewFieldMap()
+
+	if _, ok := m.Get("a"); ok {
+		t.Fatal("Get on empty FieldMap returned ok")
+	}
+
+	m.Set("a", NewValueInterface(int64(1)))
+	m.Set("b", NewValueInterface(int64(2)))
+	if m.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", m.Len())
+	}
+
+	m.Set("a", NewValueInterface(int64(10)))
+	if m.Len() != 2 {
+		t.Fatalf("Set on existing key changed Len() to %d, want 2", m.Len())
+	}
+	if v, ok := m.Get("a"); !ok || v.Unstructured() != int64(10) {
+		t.Fatalf("Get(%q) = %v, %v; want 10, true", "a", v, ok)
+	}
+
+	m.Delete("a")
+	if _, ok := m.Get("a"); ok {
+		t.Fatal("Get after Delete still returned ok")
+	}
+	if m.Len() != 1 {
+		t.Fatalf("Len() after Delete = %d, want 1", m.Len())
+	}
+
+	m.Delete("does-not-exist")
+	if m.Len() != 1 {
+		t.Fatalf("Delete of missing key changed Len() to %d, want 1", m.Len())
+	}
+}
+
+func TestFieldMapFromListRoundTrip(t *testing.T) {
+	list := FieldList{
+		{Name: "b", Value: NewValueInterface(int64(2))},
+		{Name: "a", Value: NewValueInterface(int64(1))},
+	}
+
+	m := FieldMapFromList(list)
+	got := m.ToList()
+
+	if !got.Equals(list) {
+		t.Fatalf("ToList() = %v, want insertion order preserved %v", got, list)
+	}
+}
+
+func TestFieldMapRange(t *testing.T) {
+	m := FieldMapFromList(FieldList{
+		{Name: "a", Value: NewValueInterface(int64(1))},
+		{Name: "b", Value: NewValueInterface(int64(2))},
+		{Name: "c", Value: NewValueInterface(int64(3))},
+	})
+
+	var seen []string
+	m.Range(func(name string, v Value) bool {
+		seen = append(seen, name)
+		return name != "b"
+	})
+
+	want := []string{"a", "b"}
+	if len(seen) != len(want) {
+		t.Fatalf("Range visited %v, want %v", seen, want)
+	}
+	for i := range want {
+		if seen[i] != want[i] {
+			t.Fatalf("Range visited %v, want %v", seen, want)
+		}
+	}
+}
+
+func TestFieldMapEqualsIgnoresOrder(t *testing.T) {
+	a := FieldMapFromList(FieldList{
+		{Name: "a", Value: NewValueInterface(int64(1))},
+		{Name: "b", Value: NewValueInterface(int64(2))},
+	})
+	b := FieldMapFromList(FieldList{
+		{Name: "b", Value: NewValueInterface(int64(2))},
+		{Name: "a", Value: NewValueInterface(int64(1))},
+	})
+
+	if !a.Equals(b) {
+		t.Fatal("Equals() = false for field maps with the same fields in different order")
+	}
+
+	b.Set("a", NewValueInterface(int64(99)))
+	if a.Equals(b) {
+		t.Fatal("Equals() = true after changing a field's value")
+	}
+}
+
+func TestFieldMapFromUnstructured(t *testing.T) {
+	m := FieldMapFromUnstructured(map[string]interface{}{
+		"a": int64(1),
+		"b": "two",
+	})
+
+	want := FieldList{
+		{Name: "a", Value: NewValueInterface(int64(1))},
+		{Name: "b", Value: NewValueInterface("two")},
+	}
+	if !m.ToList().sortedCopy().Equals(want.sortedCopy()) {
+		t.Fatalf("ToList() = %v, want %v", m.ToList(), want)
+	}
+}
+
+func TestFieldMapCompareMatchesFieldList(t *testing.T) {
+	a := FieldMapFromList(FieldList{
+		{Name: "b", Value: NewValueInterface(int64(2))},
+		{Name: "a", Value: NewValueInterface(int64(1))},
+	})
+	b := FieldMapFromList(FieldList{
+		{Name: "a", Value: NewValueInterface(int64(1))},
+		{Name: "b", Value: NewValueInterface(int64(2))},
+	})
+
+	if c := a.Compare(b); c != 0 {
+		t.Fatalf("Compare() = %d, want 0 for field maps with the same fields", c)
+	}
+}
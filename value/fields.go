@@ -18,11 +18,11 @@ package value
 
 import (
 	"fmt"
-	"io"
 	"sort"
 	"strings"
 
 	"sigs.k8s.io/structured-merge-diff/v4/internal/builder"
+	"sigs.k8s.io/structured-merge-diff/v4/value/tokenizer"
 )
 
 // Field is an individual key-value pair.
@@ -37,30 +37,46 @@ type FieldList []Field
 
 // FieldListFromJSON is a helper function for reading a JSON document.
 func FieldListFromJSON(input []byte) (FieldList, error) {
-	parser := builder.NewFastObjParser(input)
+	return FieldListFromJSONWith(tokenizer.NewDefault(input))
+}
+
+// FieldListFromJSONWith reads a JSON object using tok, letting callers
+// plug in an alternative JSON implementation (e.g. a SIMD or
+// codegen-based tokenizer, or a fault-injecting one for tests) without
+// forking this package.
+func FieldListFromJSONWith(tok tokenizer.JSONTokenizer) (FieldList, error) {
+	start, err := tok.Next()
+	if err != nil {
+		return nil, fmt.Errorf("parsing JSON: %v", err)
+	}
+	if start.Kind != tokenizer.TokenObjectStart {
+		return nil, fmt.Errorf("parsing JSON: expected an object")
+	}
 
 	var fields FieldList
 	for {
-		rawKey, err := parser.Parse()
-		if err == io.EOF {
-			break
-		} else if err != nil {
+		keyTok, err := tok.Next()
+		if err != nil {
 			return nil, fmt.Errorf("parsing JSON: %v", err)
 		}
+		if keyTok.Kind == tokenizer.TokenObjectEnd {
+			break
+		}
+		if keyTok.Kind != tokenizer.TokenString {
+			return nil, fmt.Errorf("parsing JSON: expected a field name")
+		}
 
-		rawValue, err := parser.Parse()
-		if err == io.EOF {
-			return nil, fmt.Errorf("unexpected EOF")
-		} else if err != nil {
+		k, err := builder.UnmarshalString(keyTok.Raw)
+		if err != nil {
 			return nil, fmt.Errorf("parsing JSON: %v", err)
 		}
 
-		k, err := builder.UnmarshalString(rawKey)
+		valTok, err := tok.Next()
 		if err != nil {
 			return nil, fmt.Errorf("parsing JSON: %v", err)
 		}
 
-		v, err := builder.UnmarshalInterface(rawValue)
+		v, err := builder.UnmarshalInterface(valTok.Raw)
 		if err != nil {
 			return nil, fmt.Errorf("parsing JSON: %v", err)
 		}
@@ -0,0 +1,76 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package value
+
+import (
+	"errors"
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/value/tokenizer"
+)
+
+func TestFieldListFromJSONWithDefaultTokenizer(t *testing.T) {
+	got, err := FieldListFromJSONWith(tokenizer.NewDefault([]byte(`{"a":1,"b":"two"}`)))
+	if err != nil {
+		t.Fatalf("FieldListFromJSONWith: %v", err)
+	}
+
+	want := FieldList{
+		{Name: "a", Value: NewValueInterface(int64(1))},
+		{Name: "b", Value: NewValueInterface("two")},
+	}
+	if !got.sortedCopy().Equals(want.sortedCopy()) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+// failingTokenizer is a from-scratch JSONTokenizer (it does not wrap the
+// built-in fast parser) that fails with err once it has emitted all of
+// tokens. It exercises
+// FieldListFromJSONWith against a tokenizer implementation other than
+// tokenizer.Default, and checks that a mid-stream tokenizer error
+// surfaces to the caller instead of being swallowed.
+type failingTokenizer struct {
+	tokens []tokenizer.Token
+	i      int
+	err    error
+}
+
+func (f *failingTokenizer) Next() (tokenizer.Token, error) {
+	if f.i >= len(f.tokens) {
+		return tokenizer.Token{}, f.err
+	}
+	tk := f.tokens[f.i]
+	f.i++
+	return tk, nil
+}
+
+func TestFieldListFromJSONWithPropagatesTokenizerError(t *testing.T) {
+	injected := errors.New("injected tokenizer failure")
+	tok := &failingTokenizer{
+		tokens: []tokenizer.Token{
+			{Kind: tokenizer.TokenObjectStart},
+			{Kind: tokenizer.TokenString, Raw: []byte(`"a"`)},
+		},
+		err: injected,
+	}
+
+	_, err := FieldListFromJSONWith(tok)
+	if err == nil {
+		t.Fatal("expected an error from a tokenizer that fails mid-stream")
+	}
+}
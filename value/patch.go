@@ -0,0 +1,427 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package value
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/structured-merge-diff/v4/internal/builder"
+)
+
+// ApplyMergePatch applies a JSON Merge Patch (RFC 7396) to f and returns
+// the result. Per the RFC, a null value in the patch deletes the
+// corresponding key and objects are merged recursively. A non-object
+// patch document would replace the target wholesale, which a FieldList
+// cannot hold, so ApplyMergePatch returns an error for it instead.
+func (f FieldList) ApplyMergePatch(patch []byte) (FieldList, error) {
+	// Parsed with builder.UnmarshalInterface, not encoding/json, so that
+	// whole numbers come back as int64 and match what FieldListFromJSON
+	// would have produced for the same JSON (encoding/json always
+	// decodes numbers as float64).
+	patchVal, err := builder.UnmarshalInterface(patch)
+	if err != nil {
+		return nil, fmt.Errorf("parsing merge patch: %v", err)
+	}
+
+	patchObj, ok := patchVal.(map[string]interface{})
+	if !ok {
+		// RFC 7396 replaces the whole target with a non-object patch
+		// document, but FieldList can only represent a JSON object, so
+		// there is nothing meaningful to return here.
+		return nil, fmt.Errorf("merge patch replaces the document with a non-object value, which cannot be represented as a FieldList")
+	}
+	return f.mergePatch(patchObj), nil
+}
+
+// mergePatch implements the object/object case of RFC 7396 directly on
+// f, preserving the existing field order and appending any new fields
+// introduced by the patch in sorted order.
+func (f FieldList) mergePatch(patch map[string]interface{}) FieldList {
+	out := make(FieldList, len(f))
+	copy(out, f)
+
+	for _, key := range sortedKeys(patch) {
+		patchVal := patch[key]
+		idx := out.indexOf(key)
+
+		if patchVal == nil {
+			if idx >= 0 {
+				out = append(out[:idx], out[idx+1:]...)
+			}
+			continue
+		}
+
+		var target interface{}
+		if idx >= 0 {
+			target = out[idx].Value.Unstructured()
+		}
+		merged := mergePatchValue(target, patchVal)
+
+		if idx >= 0 {
+			out[idx].Value = NewValueInterface(merged)
+		} else {
+			out = append(out, Field{Name: key, Value: NewValueInterface(merged)})
+		}
+	}
+	return out
+}
+
+// mergePatchValue implements the recursive case of RFC 7396: if both the
+// target and the patch are objects, they are merged field by field;
+// otherwise the patch value replaces the target outright.
+func mergePatchValue(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObj, _ := target.(map[string]interface{})
+	out := make(map[string]interface{}, len(targetObj))
+	for k, v := range targetObj {
+		out[k] = v
+	}
+	for _, k := range sortedKeys(patchObj) {
+		v := patchObj[k]
+		if v == nil {
+			delete(out, k)
+			continue
+		}
+		out[k] = mergePatchValue(out[k], v)
+	}
+	return out
+}
+
+func (f FieldList) indexOf(name string) int {
+	for i := range f {
+		if f[i].Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// fieldListFromMapPreservingOrder rebuilds a FieldList from m, keeping
+// the original field order from src for fields that survive and
+// appending any fields new to m in sorted order.
+func fieldListFromMapPreservingOrder(src FieldList, m map[string]interface{}) FieldList {
+	out := make(FieldList, 0, len(m))
+	seen := make(map[string]bool, len(m))
+	for _, field := range src {
+		if v, ok := m[field.Name]; ok {
+			out = append(out, Field{Name: field.Name, Value: NewValueInterface(v)})
+			seen[field.Name] = true
+		}
+	}
+	var added []string
+	for k := range m {
+		if !seen[k] {
+			added = append(added, k)
+		}
+	}
+	sort.Strings(added)
+	for _, k := range added {
+		out = append(out, Field{Name: k, Value: NewValueInterface(m[k])})
+	}
+	return out
+}
+
+// jsonPatchOp is a single RFC 6902 operation.
+type jsonPatchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// JSONPatchTestFailedError is returned by ApplyJSONPatch when a "test"
+// operation does not match the current document (RFC 6902 §4.6).
+type JSONPatchTestFailedError struct {
+	Path string
+}
+
+func (e *JSONPatchTestFailedError) Error() string {
+	return fmt.Sprintf("json patch: test operation failed for path %q", e.Path)
+}
+
+// ApplyJSONPatch applies a JSON Patch (RFC 6902) document to f and
+// returns the result. Field order is preserved for fields untouched by
+// the patch; fields added by the patch are appended in sorted order.
+func (f FieldList) ApplyJSONPatch(ops []byte) (FieldList, error) {
+	var patchOps []jsonPatchOp
+	if err := json.Unmarshal(ops, &patchOps); err != nil {
+		return nil, fmt.Errorf("parsing json patch: %v", err)
+	}
+
+	doc := interface{}(f.toUnstructuredMap())
+
+	for _, op := range patchOps {
+		tokens, err := splitJSONPointer(op.Path)
+		if err != nil {
+			return nil, fmt.Errorf("json patch %s: %v", op.Op, err)
+		}
+
+		switch op.Op {
+		case "add":
+			var val interface{}
+			if val, err = builder.UnmarshalInterface(op.Value); err == nil {
+				doc, err = jsonPatchAdd(doc, tokens, val)
+			}
+		case "remove":
+			doc, err = jsonPatchRemove(doc, tokens)
+		case "replace":
+			var val interface{}
+			if val, err = builder.UnmarshalInterface(op.Value); err == nil {
+				doc, err = jsonPatchRemove(doc, tokens)
+				if err == nil {
+					doc, err = jsonPatchAdd(doc, tokens, val)
+				}
+			}
+		case "move":
+			var fromTokens []string
+			var val interface{}
+			if fromTokens, err = splitJSONPointer(op.From); err == nil {
+				if val, err = jsonPatchGet(doc, fromTokens); err == nil {
+					if doc, err = jsonPatchRemove(doc, fromTokens); err == nil {
+						doc, err = jsonPatchAdd(doc, tokens, val)
+					}
+				}
+			}
+		case "copy":
+			var fromTokens []string
+			var val interface{}
+			if fromTokens, err = splitJSONPointer(op.From); err == nil {
+				if val, err = jsonPatchGet(doc, fromTokens); err == nil {
+					doc, err = jsonPatchAdd(doc, tokens, val)
+				}
+			}
+		case "test":
+			err = jsonPatchTest(doc, tokens, op.Value, op.Path)
+		default:
+			err = fmt.Errorf("unsupported json patch operation %q", op.Op)
+		}
+
+		if err != nil {
+			if testErr, ok := err.(*JSONPatchTestFailedError); ok {
+				return nil, testErr
+			}
+			return nil, fmt.Errorf("json patch %s %q: %v", op.Op, op.Path, err)
+		}
+	}
+
+	obj, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("json patch result is not a JSON object")
+	}
+	return fieldListFromMapPreservingOrder(f, obj), nil
+}
+
+// toUnstructuredMap converts f to a plain Go map so the JSON-patch
+// helpers below can address it by JSON pointer with ordinary map/slice
+// operations (pointer-addressed get/add/remove, each returning a copy).
+// Merge patches do not use it; mergePatch works on a copy of the
+// FieldList directly. This costs one Unstructured() walk over f up front;
+// fieldListFromMapPreservingOrder pays a matching cost converting back.
+func (f FieldList) toUnstructuredMap() map[string]interface{} {
+	out := make(map[string]interface{}, len(f))
+	for _, field := range f {
+		out[field.Name] = field.Value.Unstructured()
+	}
+	return out
+}
+
+// splitJSONPointer splits and unescapes an RFC 6901 JSON pointer.
+func splitJSONPointer(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	if path[0] != '/' {
+		return nil, fmt.Errorf("json pointer %q must start with '/'", path)
+	}
+	parts := strings.Split(path[1:], "/")
+	for i, p := range parts {
+		p = strings.ReplaceAll(p, "~1", "/")
+		p = strings.ReplaceAll(p, "~0", "~")
+		parts[i] = p
+	}
+	return parts, nil
+}
+
+func jsonPatchGet(doc interface{}, tokens []string) (interface{}, error) {
+	cur := doc
+	for _, t := range tokens {
+		switch node := cur.(type) {
+		case map[string]interface{}:
+			v, ok := node[t]
+			if !ok {
+				return nil, fmt.Errorf("no such key %q", t)
+			}
+			cur = v
+		case []interface{}:
+			i, err := strconv.Atoi(t)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, fmt.Errorf("invalid array index %q", t)
+			}
+			cur = node[i]
+		default:
+			return nil, fmt.Errorf("cannot descend into scalar at %q", t)
+		}
+	}
+	return cur, nil
+}
+
+// jsonPatchAdd returns a copy of doc with val inserted at tokens,
+// handling the RFC 6902 "-" array-append token.
+func jsonPatchAdd(doc interface{}, tokens []string, val interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		return val, nil
+	}
+	tok, rest := tokens[0], tokens[1:]
+
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(node)+1)
+		for k, v := range node {
+			out[k] = v
+		}
+		if len(rest) == 0 {
+			out[tok] = val
+			return out, nil
+		}
+		child, ok := out[tok]
+		if !ok {
+			return nil, fmt.Errorf("no such key %q", tok)
+		}
+		newChild, err := jsonPatchAdd(child, rest, val)
+		if err != nil {
+			return nil, err
+		}
+		out[tok] = newChild
+		return out, nil
+
+	case []interface{}:
+		idx := len(node)
+		if tok != "-" {
+			i, err := strconv.Atoi(tok)
+			if err != nil || i < 0 || i > len(node) {
+				return nil, fmt.Errorf("invalid array index %q", tok)
+			}
+			idx = i
+		}
+		if len(rest) == 0 {
+			out := make([]interface{}, 0, len(node)+1)
+			out = append(out, node[:idx]...)
+			out = append(out, val)
+			out = append(out, node[idx:]...)
+			return out, nil
+		}
+		if idx >= len(node) {
+			return nil, fmt.Errorf("invalid array index %q", tok)
+		}
+		newChild, err := jsonPatchAdd(node[idx], rest, val)
+		if err != nil {
+			return nil, err
+		}
+		out := append([]interface{}(nil), node...)
+		out[idx] = newChild
+		return out, nil
+
+	default:
+		return nil, fmt.Errorf("cannot descend into scalar at %q", tok)
+	}
+}
+
+// jsonPatchRemove returns a copy of doc with the value at tokens removed.
+func jsonPatchRemove(doc interface{}, tokens []string) (interface{}, error) {
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("cannot remove the document root")
+	}
+	tok, rest := tokens[0], tokens[1:]
+
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		if _, ok := node[tok]; !ok {
+			return nil, fmt.Errorf("no such key %q", tok)
+		}
+		if len(rest) == 0 {
+			out := make(map[string]interface{}, len(node)-1)
+			for k, v := range node {
+				if k != tok {
+					out[k] = v
+				}
+			}
+			return out, nil
+		}
+		newChild, err := jsonPatchRemove(node[tok], rest)
+		if err != nil {
+			return nil, err
+		}
+		out := make(map[string]interface{}, len(node))
+		for k, v := range node {
+			out[k] = v
+		}
+		out[tok] = newChild
+		return out, nil
+
+	case []interface{}:
+		i, err := strconv.Atoi(tok)
+		if err != nil || i < 0 || i >= len(node) {
+			return nil, fmt.Errorf("invalid array index %q", tok)
+		}
+		if len(rest) == 0 {
+			out := make([]interface{}, 0, len(node)-1)
+			out = append(out, node[:i]...)
+			out = append(out, node[i+1:]...)
+			return out, nil
+		}
+		newChild, err := jsonPatchRemove(node[i], rest)
+		if err != nil {
+			return nil, err
+		}
+		out := append([]interface{}(nil), node...)
+		out[i] = newChild
+		return out, nil
+
+	default:
+		return nil, fmt.Errorf("cannot descend into scalar at %q", tok)
+	}
+}
+
+func jsonPatchTest(doc interface{}, tokens []string, raw json.RawMessage, path string) error {
+	want, err := builder.UnmarshalInterface(raw)
+	if err != nil {
+		return fmt.Errorf("parsing test value: %v", err)
+	}
+	got, err := jsonPatchGet(doc, tokens)
+	if err != nil || !reflect.DeepEqual(got, want) {
+		return &JSONPatchTestFailedError{Path: path}
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package value
+
+import "testing"
+
+func mustFieldListFromJSON(t *testing.T, doc string) FieldList {
+	t.Helper()
+	f, err := FieldListFromJSON([]byte(doc))
+	if err != nil {
+		t.Fatalf("FieldListFromJSON(%q): %v", doc, err)
+	}
+	return f
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	cases := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+	}{
+		{
+			name:  "replace scalar",
+			doc:   `{"a":1,"b":2}`,
+			patch: `{"a":3}`,
+			want:  `{"a":3,"b":2}`,
+		},
+		{
+			name:  "null deletes key",
+			doc:   `{"a":1,"b":2}`,
+			patch: `{"a":null}`,
+			want:  `{"b":2}`,
+		},
+		{
+			name:  "recursive object merge",
+			doc:   `{"a":{"x":1,"y":2}}`,
+			patch: `{"a":{"y":3,"z":4}}`,
+			want:  `{"a":{"x":1,"y":3,"z":4}}`,
+		},
+		{
+			name:  "non-object patch value replaces target field",
+			doc:   `{"a":{"x":1}}`,
+			patch: `{"a":[1,2,3]}`,
+			want:  `{"a":[1,2,3]}`,
+		},
+		{
+			name:  "new field added",
+			doc:   `{"a":1}`,
+			patch: `{"b":2}`,
+			want:  `{"a":1,"b":2}`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			doc := mustFieldListFromJSON(t, c.doc)
+			want := mustFieldListFromJSON(t, c.want)
+
+			got, err := doc.ApplyMergePatch([]byte(c.patch))
+			if err != nil {
+				t.Fatalf("ApplyMergePatch: %v", err)
+			}
+			if !got.sortedCopy().Equals(want.sortedCopy()) {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestApplyMergePatchNonObjectReplacementErrors(t *testing.T) {
+	doc := mustFieldListFromJSON(t, `{"a":1}`)
+	if _, err := doc.ApplyMergePatch([]byte(`[1,2,3]`)); err == nil {
+		t.Fatal("expected an error replacing a FieldList with a non-object merge patch")
+	}
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	cases := []struct {
+		name string
+		doc  string
+		ops  string
+		want string
+	}{
+		{
+			name: "add field",
+			doc:  `{"a":1}`,
+			ops:  `[{"op":"add","path":"/b","value":2}]`,
+			want: `{"a":1,"b":2}`,
+		},
+		{
+			name: "remove field",
+			doc:  `{"a":1,"b":2}`,
+			ops:  `[{"op":"remove","path":"/a"}]`,
+			want: `{"b":2}`,
+		},
+		{
+			name: "replace field",
+			doc:  `{"a":1}`,
+			ops:  `[{"op":"replace","path":"/a","value":2}]`,
+			want: `{"a":2}`,
+		},
+		{
+			name: "move field",
+			doc:  `{"a":1}`,
+			ops:  `[{"op":"move","from":"/a","path":"/b"}]`,
+			want: `{"b":1}`,
+		},
+		{
+			name: "copy field",
+			doc:  `{"a":1}`,
+			ops:  `[{"op":"copy","from":"/a","path":"/b"}]`,
+			want: `{"a":1,"b":1}`,
+		},
+		{
+			name: "add array append token",
+			doc:  `{"a":[1,2]}`,
+			ops:  `[{"op":"add","path":"/a/-","value":3}]`,
+			want: `{"a":[1,2,3]}`,
+		},
+		{
+			name: "passing test lets later operations apply",
+			doc:  `{"a":1}`,
+			ops:  `[{"op":"test","path":"/a","value":1},{"op":"add","path":"/b","value":2}]`,
+			want: `{"a":1,"b":2}`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			doc := mustFieldListFromJSON(t, c.doc)
+			want := mustFieldListFromJSON(t, c.want)
+
+			got, err := doc.ApplyJSONPatch([]byte(c.ops))
+			if err != nil {
+				t.Fatalf("ApplyJSONPatch: %v", err)
+			}
+			if !got.sortedCopy().Equals(want.sortedCopy()) {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestApplyJSONPatchTestFailure(t *testing.T) {
+	doc := mustFieldListFromJSON(t, `{"a":1}`)
+
+	_, err := doc.ApplyJSONPatch([]byte(`[{"op":"test","path":"/a","value":2}]`))
+	if err == nil {
+		t.Fatal("expected a test failure error")
+	}
+	if _, ok := err.(*JSONPatchTestFailedError); !ok {
+		t.Fatalf("got error of type %T, want *JSONPatchTestFailedError", err)
+	}
+}
+
+func TestApplyJSONPatchRemoveMissingFieldErrors(t *testing.T) {
+	doc := mustFieldListFromJSON(t, `{"a":1}`)
+	if _, err := doc.ApplyJSONPatch([]byte(`[{"op":"remove","path":"/missing"}]`)); err == nil {
+		t.Fatal("expected an error removing a field that doesn't exist")
+	}
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tokenizer
+
+import (
+	"fmt"
+	"io"
+
+	"sigs.k8s.io/structured-merge-diff/v4/internal/builder"
+)
+
+// Default adapts the package's existing fast object parser to the
+// JSONTokenizer interface. Because that parser already returns whole
+// key/value pairs rather than individual lexical tokens, field values
+// are surfaced as a single TokenRawValue instead of being broken down
+// further.
+type Default struct {
+	next func() (Token, error)
+}
+
+// NewDefault returns a JSONTokenizer over input backed by the package's
+// built-in fast object parser.
+func NewDefault(input []byte) *Default {
+	parser := builder.NewFastObjParser(input)
+	started := false
+	expectValue := false
+
+	return &Default{next: func() (Token, error) {
+		if !started {
+			started = true
+			return Token{Kind: TokenObjectStart}, nil
+		}
+
+		if expectValue {
+			raw, err := parser.Parse()
+			if err != nil {
+				return Token{}, fmt.Errorf("reading value: %v", err)
+			}
+			expectValue = false
+			return Token{Kind: TokenRawValue, Raw: raw}, nil
+		}
+
+		raw, err := parser.Parse()
+		if err == io.EOF {
+			return Token{Kind: TokenObjectEnd}, nil
+		} else if err != nil {
+			return Token{}, fmt.Errorf("reading key: %v", err)
+		}
+		expectValue = true
+		return Token{Kind: TokenString, Raw: raw}, nil
+	}}
+}
+
+// Next implements JSONTokenizer.
+func (d *Default) Next() (Token, error) {
+	return d.next()
+}
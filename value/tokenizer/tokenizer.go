@@ -0,0 +1,68 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tokenizer defines the JSONTokenizer interface used to plug
+// alternative JSON implementations into FieldListFromJSONWith, and ships
+// the default implementation backed by the value package's built-in fast
+// parser.
+//
+// JSONTokenizer is deliberately narrower than a general per-lexeme JSON
+// tokenizer: it models a top-level object as a flat stream of field
+// names paired with opaque, not-further-tokenized field values, because
+// that is the granularity FieldListFromJSON has ever needed and the
+// granularity the built-in fast parser actually produces. A tokenizer
+// that wants to expose, say, individual array elements or numbers as
+// distinct tokens would need its own consumer; FieldListFromJSONWith
+// only understands this object-of-raw-values shape.
+package tokenizer
+
+// TokenKind identifies the kind of a Token.
+type TokenKind int
+
+const (
+	// TokenObjectStart marks the beginning of the top-level object. It is
+	// always the first token produced.
+	TokenObjectStart TokenKind = iota
+	// TokenObjectEnd marks the end of the top-level object. It is always
+	// the last token produced.
+	TokenObjectEnd
+	// TokenString carries a field name, as raw (still-quoted) JSON.
+	TokenString
+	// TokenRawValue carries a field's value as raw, unparsed JSON. It is
+	// handed to builder.UnmarshalInterface by the consumer, not broken
+	// down into further tokens.
+	TokenRawValue
+)
+
+// Token is a single element produced by a JSONTokenizer. Raw holds the
+// underlying bytes for TokenString and TokenRawValue; it is nil for the
+// structural tokens.
+type Token struct {
+	Kind TokenKind
+	Raw  []byte
+}
+
+// JSONTokenizer produces the token stream FieldListFromJSONWith reads: a
+// TokenObjectStart, then an alternating TokenString/TokenRawValue pair
+// per field, then a TokenObjectEnd. Implementations may plug in
+// alternative JSON decoders (SIMD or codegen-based parsers, fault-
+// injecting parsers for tests) without forking the value package, as
+// long as they produce this shape.
+type JSONTokenizer interface {
+	// Next returns the next token. Next is not called again after it
+	// returns a TokenObjectEnd.
+	Next() (Token, error)
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tokenizer
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDefaultTokenStream(t *testing.T) {
+	tok := NewDefault([]byte(`{"a":1,"b":"two"}`))
+
+	var got []Token
+	for {
+		tk, err := tok.Next()
+		if err != nil {
+			t.Fatalf("Next(): %v", err)
+		}
+		got = append(got, tk)
+		if tk.Kind == TokenObjectEnd {
+			break
+		}
+	}
+
+	want := []TokenKind{
+		TokenObjectStart,
+		TokenString, TokenRawValue,
+		TokenString, TokenRawValue,
+		TokenObjectEnd,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d tokens, want %d: %v", len(got), len(want), got)
+	}
+	for i, k := range want {
+		if got[i].Kind != k {
+			t.Fatalf("token %d: got kind %v, want %v", i, got[i].Kind, k)
+		}
+	}
+}
+
+// fixedTokenizer replays a canned token sequence, then fails with err.
+// The tests below check that such a from-scratch JSONTokenizer (not
+// wrapping the built-in fast parser at all) satisfies the interface and
+// hands back its injected error once its tokens run out; they call Next
+// directly and do not drive a consumer.
+type fixedTokenizer struct {
+	tokens []Token
+	err    error
+}
+
+func (f *fixedTokenizer) Next() (Token, error) {
+	if len(f.tokens) == 0 {
+		return Token{}, f.err
+	}
+	tk := f.tokens[0]
+	f.tokens = f.tokens[1:]
+	return tk, nil
+}
+
+func TestFixedTokenizerIsAJSONTokenizer(t *testing.T) {
+	var tok JSONTokenizer = &fixedTokenizer{
+		tokens: []Token{
+			{Kind: TokenObjectStart},
+			{Kind: TokenString, Raw: []byte(`"a"`)},
+			{Kind: TokenRawValue, Raw: []byte(`1`)},
+			{Kind: TokenObjectEnd},
+		},
+	}
+
+	for i := 0; i < 4; i++ {
+		if _, err := tok.Next(); err != nil {
+			t.Fatalf("Next() #%d: %v", i, err)
+		}
+	}
+}
+
+func TestFixedTokenizerErrorPropagates(t *testing.T) {
+	wantErr := errors.New("injected failure")
+	tok := &fixedTokenizer{
+		tokens: []Token{{Kind: TokenObjectStart}},
+		err:    wantErr,
+	}
+
+	if _, err := tok.Next(); err != nil {
+		t.Fatalf("Next() #1: %v", err)
+	}
+	if _, err := tok.Next(); err != wantErr {
+		t.Fatalf("Next() #2: got err %v, want %v", err, wantErr)
+	}
+}